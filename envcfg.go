@@ -1,114 +1,341 @@
 // Package envcfg provides functions to load values to a structure fields from .env file and from OS environment variables.
 //
-// Usage
+// # Usage
 //
 // Declare a structure and use tag `env` to define associated environment variable names for desired fields.
 //
-// 	type Cfg struct {
-// 		Debug       bool   `env:"DEBUG"`
-// 		DatabaseURL string `env:"DATABASE_URL"`
-// 	}
+//	type Cfg struct {
+//		Debug       bool   `env:"DEBUG"`
+//		DatabaseURL string `env:"DATABASE_URL"`
+//	}
 //
 // Create a new structure to provide default values.
 //
-//  cfg := Cfg{
-//  	Debug: true,
-//  	DatabaseURL: "sqlite:///db.sqlite",
-//  }
+//	cfg := Cfg{
+//		Debug: true,
+//		DatabaseURL: "sqlite:///db.sqlite",
+//	}
 //
 // Call envcfg.Load() to load values from environment variables.
 //
-//  err := envcfg.Load(&cfg)
+//	err := envcfg.Load(&cfg)
 //
 // Keep in mind that the values are first loaded from the .env file (if it exists) and then
 // from the OS environment variables that can override the values loaded from the file.
 //
 // The syntax of the .env file should follow these rules:
 //
-//  - Each line should be in VAR=VAL format
-//  - Lines beginning with # are processed as comments and ignored
-//  - Blank lines are ignored
+//   - Each line should be in VAR=VAL format, optionally prefixed with "export "
+//   - Lines beginning with # are processed as comments and ignored
+//   - Blank lines are ignored
+//   - Values may be single- or double-quoted to preserve spaces and "#"; double
+//     quotes also support "\n", "\t" and "\"" escapes and may span multiple lines
+//   - "${VAR}" and "$VAR" expand to a previously parsed value or, failing that, the
+//     process environment; "${VAR:-default}" falls back to default if VAR is unset
 //
-// Notes
+// # Notes
 //
 // A limited number of field types are supported, but they should be enough for most cases.
 // Nested structures are supported, just mark nested fields with `env` tag as usual
 // (no special syntax for .env file required). To load .env file from a different location or
 // with a different name use LoadFile() function.
+//
+// Slices, arrays and maps are also supported. The value is split on a separator (`,` by
+// default, override with the `envSeparator` tag) and each element is parsed using the same
+// rules as a scalar field. Map entries additionally split each pair into key and value on
+// `:` (override with the `envKvSeparator` tag). `time.Duration` fields are parsed with
+// time.ParseDuration, and `time.Time` fields are parsed as RFC3339 unless a different
+// layout is given via the `envLayout` tag.
+//
+// Use `envRequired:"true"` to fail loading when a variable is absent from both the file
+// and the OS environment, and `envDefault:"..."` to fall back to a default value in that
+// case (the default goes through the same parsing as a real value, so types are still
+// coerced). All field failures are collected and returned together as a *LoadError
+// instead of stopping at the first one.
+//
+// Types envcfg doesn't know about can implement the Setter interface, or register a
+// parser with RegisterParser, to plug into the same loading pipeline as the built-in
+// types. Pointer fields are allocated automatically and unmarshalled into.
+//
+// Besides .env files, JSON, YAML and TOML files are also supported out of the box,
+// selected by file extension; structured files are flattened into the same name ->
+// value map .env files produce, joining nested keys with ".". Use RegisterFileDecoder
+// to add support for other formats, and LoadFiles to merge several files in order
+// before the OS environment is applied.
+//
+// The `env` tag is optional when using LoadWithPrefix: a field without one gets its
+// variable name derived from the Go field name (SCREAMING_SNAKE_CASE) and prefixed,
+// with nested structs contributing their own name or an `envPrefix` tag to the chain.
 package envcfg
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
 )
 
 type structField struct {
-	Name  string
-	Value *reflect.Value
+	Name string
+	// Value resolves the field to write to, allocating any nil pointer in its
+	// chain (its own, or an ancestor struct's) the first time it's called. It
+	// must not be called until a value has actually been found for the field,
+	// so that a field envcfg never touches is left exactly as the caller set it.
+	Value func() *reflect.Value
+	Tag   reflect.StructTag
 }
 
-type iterable interface {
-	Iter() bool
-	Next() string
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+	setterType   = reflect.TypeOf((*Setter)(nil)).Elem()
+)
+
+// Setter is implemented by types that want to control how they are parsed from a
+// string, such as net.IP, url.URL or a custom enum. When a field's type implements
+// Setter, UnmarshalEnv takes precedence over the built-in parsing logic.
+type Setter interface {
+	UnmarshalEnv(value string) error
 }
 
-type arrayIter struct {
-	Index   int
-	Environ *[]string
+var customParsers = make(map[reflect.Type]func(string) (interface{}, error))
+
+// RegisterParser teaches envcfg how to parse env values into a type it doesn't own,
+// such as net.IP, url.URL or uuid.UUID, without having to implement Setter on it.
+func RegisterParser(t reflect.Type, parser func(string) (interface{}, error)) {
+	customParsers[t] = parser
 }
 
-func newArrayIter(arr *[]string) *arrayIter {
-	return &arrayIter{
-		Index:   -1,
-		Environ: arr,
+// hasCustomDecoder reports whether t is decoded by a registered parser or by
+// implementing Setter, in which case parseStruct must treat it as a leaf field
+// instead of descending into it.
+func hasCustomDecoder(t reflect.Type) bool {
+	if _, ok := customParsers[t]; ok {
+		return true
 	}
+	return reflect.PtrTo(t).Implements(setterType)
 }
 
-func (ai *arrayIter) Iter() bool {
-	ai.Index += 1
-	return ai.Index < len(*ai.Environ)
+// loadFromEnv reads the OS environment directly, bypassing the .env tokenizer: values
+// coming from os.Environ() are already decoded, so running them through the .env
+// parser would only risk mangling them (e.g. a value that happens to start with a
+// quote character).
+func loadFromEnv() map[string]string {
+	environ := os.Environ()
+	vars := make(map[string]string, len(environ))
+
+	for _, kv := range environ {
+		tokens := strings.SplitN(kv, "=", 2)
+		if len(tokens) == 2 {
+			vars[tokens[0]] = tokens[1]
+		}
+	}
+
+	return vars
 }
 
-func (ai *arrayIter) Next() string {
-	return (*ai.Environ)[ai.Index]
+// FileDecoder decodes a configuration file into a flat name -> value map, the same
+// shape readSource produces for .env files. Register one with RegisterFileDecoder
+// to teach LoadFile/LoadFiles a new file format.
+type FileDecoder interface {
+	// Format returns the file extension (without the leading dot) this decoder handles.
+	Format() string
+	// Decode reads r and flattens its content into vars.
+	Decode(r io.Reader, vars map[string]string) error
 }
 
-func loadFromEnv(fields []*structField) error {
-	environ := os.Environ()
-	return loadFromSource(newArrayIter(&environ), fields)
+var fileDecoders = make(map[string]FileDecoder)
+
+// RegisterFileDecoder teaches LoadFile/LoadFiles how to decode files whose extension
+// matches decoder.Format().
+func RegisterFileDecoder(decoder FileDecoder) {
+	fileDecoders[decoder.Format()] = decoder
+}
+
+func init() {
+	RegisterFileDecoder(&envFileDecoder{})
+	RegisterFileDecoder(&jsonFileDecoder{})
+	RegisterFileDecoder(&yamlFileDecoder{})
+	RegisterFileDecoder(&tomlFileDecoder{})
+}
+
+type envFileDecoder struct{}
+
+func (d *envFileDecoder) Format() string {
+	return "env"
+}
+
+func (d *envFileDecoder) Decode(r io.Reader, vars map[string]string) error {
+	parsed, err := readSource(r)
+	if err != nil {
+		return err
+	}
+	for k, v := range parsed {
+		vars[k] = v
+	}
+	return nil
+}
+
+type jsonFileDecoder struct{}
+
+func (d *jsonFileDecoder) Format() string {
+	return "json"
 }
 
-type scannerIter struct {
-	Scanner *bufio.Scanner
+func (d *jsonFileDecoder) Decode(r io.Reader, vars map[string]string) error {
+	decoder := json.NewDecoder(r)
+	// Decode numbers as json.Number instead of float64, or large integers (byte
+	// sizes, unix timestamps, nanosecond durations) would be rendered in scientific
+	// notation, which an int field fails to parse and a string field stores as is.
+	decoder.UseNumber()
+
+	var data map[string]interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return err
+	}
+	flattenVars("", data, vars)
+	return nil
+}
+
+type yamlFileDecoder struct{}
+
+func (d *yamlFileDecoder) Format() string {
+	return "yaml"
+}
+
+func (d *yamlFileDecoder) Decode(r io.Reader, vars map[string]string) error {
+	var data map[string]interface{}
+	if err := yaml.NewDecoder(r).Decode(&data); err != nil {
+		return err
+	}
+	flattenVars("", data, vars)
+	return nil
 }
 
-func (si *scannerIter) Iter() bool {
-	return si.Scanner.Scan()
+type tomlFileDecoder struct{}
+
+func (d *tomlFileDecoder) Format() string {
+	return "toml"
 }
 
-func (si *scannerIter) Next() string {
-	return si.Scanner.Text()
+func (d *tomlFileDecoder) Decode(r io.Reader, vars map[string]string) error {
+	var data map[string]interface{}
+	if _, err := toml.NewDecoder(r).Decode(&data); err != nil {
+		return err
+	}
+	flattenVars("", data, vars)
+	return nil
 }
 
-func loadFromFile(filename string, fields []*structField) error {
+// flattenVars flattens a structured document into the same name -> value map
+// loadFromSource consumes for .env files, joining nested keys with ".". Lists are
+// joined with the default envSeparator (",") so they still parse with setSlice/setMap.
+func flattenVars(prefix string, data map[string]interface{}, vars map[string]string) {
+	for key, val := range data {
+		name := key
+		if prefix != "" {
+			name = prefix + "." + key
+		}
+
+		if nested, ok := val.(map[string]interface{}); ok {
+			flattenVars(name, nested, vars)
+			continue
+		}
+
+		vars[name] = flattenScalar(val)
+	}
+}
+
+// flattenScalar renders a single decoded value (or list of them) as the string form
+// setValue expects, taking care not to lose precision on json.Number values.
+func flattenScalar(val interface{}) string {
+	switch v := val.(type) {
+	case json.Number:
+		return v.String()
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, elem := range v {
+			parts[i] = flattenScalar(elem)
+		}
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func decodeFile(filename string) (map[string]string, error) {
+	ext := strings.TrimPrefix(path.Ext(filename), ".")
+
+	// Any extension without a registered decoder (including none at all) is treated
+	// as a .env file, so dotenv-flow-style names like ".env.local"/".env.production"
+	// keep working instead of failing on their trailing suffix.
+	decoder, ok := fileDecoders[ext]
+	if !ok {
+		decoder = fileDecoders["env"]
+	}
+
 	file, err := os.Open(filename)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer file.Close()
 
-	return loadFromSource(&scannerIter{
-		Scanner: bufio.NewScanner(file),
-	}, fields)
+	vars := make(map[string]string)
+	if err := decoder.Decode(file, vars); err != nil {
+		return nil, err
+	}
+
+	return vars, nil
 }
 
-func setValue(field *reflect.Value, value string) error {
+func setValue(field *reflect.Value, value string, tag reflect.StructTag) error {
+	if field.CanAddr() {
+		if setter, ok := field.Addr().Interface().(Setter); ok {
+			return setter.UnmarshalEnv(value)
+		}
+	}
+
+	if parser, ok := customParsers[field.Type()]; ok {
+		parsed, err := parser(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(parsed))
+		return nil
+	}
+
+	switch field.Type() {
+	case durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	case timeType:
+		layout := tag.Get("envLayout")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+
 	k := field.Kind()
 
 	switch {
@@ -138,6 +365,10 @@ func setValue(field *reflect.Value, value string) error {
 		field.SetFloat(f)
 	case k == reflect.String:
 		field.SetString(value)
+	case k == reflect.Slice || k == reflect.Array:
+		return setSlice(field, value, tag)
+	case k == reflect.Map:
+		return setMap(field, value, tag)
 	default:
 		return fmt.Errorf("field %s is not supported", field.Kind())
 	}
@@ -145,85 +376,438 @@ func setValue(field *reflect.Value, value string) error {
 	return nil
 }
 
-func readSource(source iterable) (*map[string]string, error) {
+func separator(tag reflect.StructTag) string {
+	if sep := tag.Get("envSeparator"); sep != "" {
+		return sep
+	}
+	return ","
+}
+
+func kvSeparator(tag reflect.StructTag) string {
+	if sep := tag.Get("envKvSeparator"); sep != "" {
+		return sep
+	}
+	return ":"
+}
+
+func setSlice(field *reflect.Value, value string, tag reflect.StructTag) error {
+	var parts []string
+	if trimmed := strings.TrimSpace(value); trimmed != "" {
+		parts = strings.Split(value, separator(tag))
+	}
+	elemType := field.Type().Elem()
+	slice := reflect.MakeSlice(reflect.SliceOf(elemType), len(parts), len(parts))
+
+	for i, part := range parts {
+		elem := slice.Index(i)
+		if err := setValue(&elem, strings.TrimSpace(part), tag); err != nil {
+			return err
+		}
+	}
+
+	if field.Kind() == reflect.Array {
+		if field.Len() != len(parts) {
+			return fmt.Errorf("array field expects %d elements, got %d", field.Len(), len(parts))
+		}
+		reflect.Copy(*field, slice)
+		return nil
+	}
+
+	field.Set(slice)
+	return nil
+}
+
+func setMap(field *reflect.Value, value string, tag reflect.StructTag) error {
+	mapType := field.Type()
+	m := reflect.MakeMap(mapType)
+	kvSep := kvSeparator(tag)
+
+	for _, pair := range strings.Split(value, separator(tag)) {
+		tokens := strings.SplitN(pair, kvSep, 2)
+		if len(tokens) != 2 {
+			return fmt.Errorf("map entry must be in key%svalue format: %v", kvSep, pair)
+		}
+
+		key := reflect.New(mapType.Key()).Elem()
+		if err := setValue(&key, strings.TrimSpace(tokens[0]), tag); err != nil {
+			return err
+		}
+
+		val := reflect.New(mapType.Elem()).Elem()
+		if err := setValue(&val, strings.TrimSpace(tokens[1]), tag); err != nil {
+			return err
+		}
+
+		m.SetMapIndex(key, val)
+	}
+
+	field.Set(m)
+	return nil
+}
+
+// varRefPattern matches ${VAR}, ${VAR:-default} and $VAR references inside a value.
+var varRefPattern = regexp.MustCompile(`\$\{(\w+)(:-([^}]*))?\}|\$(\w+)`)
+
+// expandVars resolves ${VAR} / $VAR references in value against vars already parsed
+// earlier in the file and, failing that, the process environment. ${VAR:-default}
+// falls back to default when VAR is set nowhere. A reference with no fallback that
+// resolves nowhere is left as literal text, so values that merely contain a "$"
+// (password hashes, JWTs) round-trip unchanged instead of being blanked out.
+func expandVars(value string, vars map[string]string) string {
+	return varRefPattern.ReplaceAllStringFunc(value, func(match string) string {
+		groups := varRefPattern.FindStringSubmatch(match)
+		name, hasFallback, fallback := groups[1], groups[2] != "", groups[3]
+		if name == "" {
+			name = groups[4]
+		}
+
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		if v, ok := os.LookupEnv(name); ok {
+			return v
+		}
+		if hasFallback {
+			return fallback
+		}
+		return match
+	})
+}
+
+// findClosingQuote returns the index of the next unescaped occurrence of quote in s,
+// or -1 if there isn't one. Escaping is only meaningful inside double quotes.
+func findClosingQuote(s string, quote byte) int {
+	escaped := false
+	for i := 0; i < len(s); i++ {
+		if escaped {
+			escaped = false
+			continue
+		}
+		switch s[i] {
+		case '\\':
+			if quote == '"' {
+				escaped = true
+			}
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// unescapeDouble resolves the escape sequences recognized inside double-quoted values.
+func unescapeDouble(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case 'n':
+				b.WriteByte('\n')
+				i++
+				continue
+			case 't':
+				b.WriteByte('\t')
+				i++
+				continue
+			case '"':
+				b.WriteByte('"')
+				i++
+				continue
+			case '\\':
+				b.WriteByte('\\')
+				i++
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// decodeValue resolves the value half of a VAR=VALUE line. Unquoted values are
+// trimmed and cut at an inline " #" comment; quoted values may span multiple lines,
+// in which case more lines are pulled from reader until the closing quote is found.
+// The returned bool reports whether the value was single-quoted, in which case it
+// must be taken literally: no escapes and no "${VAR}" expansion, matching shell
+// and docker-compose/direnv .env semantics.
+func decodeValue(raw string, reader *bufio.Reader) (string, bool, error) {
+	if raw == "" {
+		return "", false, nil
+	}
+
+	quote := raw[0]
+	if quote != '"' && quote != '\'' {
+		if idx := strings.Index(raw, " #"); idx >= 0 {
+			raw = raw[:idx]
+		}
+		return strings.TrimSpace(raw), false, nil
+	}
+
+	body := raw[1:]
+	for {
+		if end := findClosingQuote(body, quote); end >= 0 {
+			content := body[:end]
+			if quote == '"' {
+				content = unescapeDouble(content)
+			}
+			return content, quote == '\'', nil
+		}
+
+		line, err := reader.ReadString('\n')
+		if line == "" {
+			if err != nil {
+				return "", false, fmt.Errorf("unterminated quoted value")
+			}
+			continue
+		}
+		body += "\n" + strings.TrimRight(line, "\r\n")
+		if err != nil {
+			if end := findClosingQuote(body, quote); end >= 0 {
+				content := body[:end]
+				if quote == '"' {
+					content = unescapeDouble(content)
+				}
+				return content, quote == '\'', nil
+			}
+			return "", false, fmt.Errorf("unterminated quoted value")
+		}
+	}
+}
+
+// readSource tokenizes the content of a .env file into a name -> value map. It
+// understands single- and double-quoted values (including embedded spaces and
+// "#"), "\n"/"\t"/"\"" escapes inside double quotes, a leading "export " prefix,
+// values whose quotes span multiple lines, and "${VAR}"/"$VAR" expansion (with
+// "${VAR:-default}" fallback) against previously parsed keys and the process
+// environment. Single-quoted values are taken literally and are not expanded,
+// matching shell semantics.
+func readSource(r io.Reader) (map[string]string, error) {
 	vars := make(map[string]string)
+	reader := bufio.NewReader(r)
 
-	for source.Iter() {
-		line := strings.TrimSpace(source.Next())
+	for {
+		rawLine, readErr := reader.ReadString('\n')
+		if readErr != nil && rawLine == "" {
+			break
+		}
 
-		// Skip blank lines and comments
-		if (len(line) == 0) || strings.HasPrefix(line, "#") {
+		line := strings.TrimSpace(rawLine)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			if readErr != nil {
+				break
+			}
 			continue
 		}
 
+		line = strings.TrimPrefix(line, "export ")
+
 		tokens := strings.SplitN(line, "=", 2)
 		if len(tokens) != 2 {
 			return nil, fmt.Errorf("key and value must be separated by the sign '=': %v", line)
 		}
 
 		key := strings.TrimSpace(tokens[0])
-		value := strings.TrimSpace(tokens[1])
+		rawValue := strings.TrimLeft(tokens[1], " \t")
+
+		value, literal, err := decodeValue(rawValue, reader)
+		if err != nil {
+			return nil, err
+		}
+
+		if literal {
+			vars[key] = value
+		} else {
+			vars[key] = expandVars(value, vars)
+		}
 
-		vars[key] = value
+		if readErr != nil {
+			break
+		}
 	}
 
-	return &vars, nil
+	return vars, nil
 }
 
-func loadFromSource(source iterable, fields []*structField) error {
-	vars, err := readSource(source)
-	if err != nil {
-		return err
+// FieldError describes why a single field failed to load.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (fe *FieldError) Error() string {
+	return fmt.Sprintf("%s: %v", fe.Field, fe.Err)
+}
+
+func (fe *FieldError) Unwrap() error {
+	return fe.Err
+}
+
+// LoadError is returned by Load, LoadFile and LoadFiles when one or more fields failed
+// to load. Unlike a plain error it collects every failure instead of aborting on the
+// first one, so callers can report all problems with the environment at once.
+type LoadError struct {
+	Errors []*FieldError
+}
+
+func (le *LoadError) Error() string {
+	msgs := make([]string, len(le.Errors))
+	for i, err := range le.Errors {
+		msgs[i] = err.Error()
 	}
+	return fmt.Sprintf("envcfg: %d field(s) failed to load:\n%s", len(le.Errors), strings.Join(msgs, "\n"))
+}
+
+func (le *LoadError) Unwrap() []error {
+	errs := make([]error, len(le.Errors))
+	for i, err := range le.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+func applyFields(fields []*structField, vars map[string]string) error {
+	loadErr := &LoadError{}
 
 	for _, field := range fields {
-		if data, ok := (*vars)[field.Name]; ok {
-			err := setValue(field.Value, data)
-			if err != nil {
-				return err
+		data, ok := vars[field.Name]
+		if !ok {
+			if def, hasDefault := field.Tag.Lookup("envDefault"); hasDefault {
+				data, ok = def, true
 			}
 		}
+
+		if !ok {
+			if field.Tag.Get("envRequired") == "true" {
+				loadErr.Errors = append(loadErr.Errors, &FieldError{
+					Field: field.Name,
+					Err:   errors.New("required environment variable is not set"),
+				})
+			}
+			continue
+		}
+
+		if err := setValue(field.Value(), data, field.Tag); err != nil {
+			loadErr.Errors = append(loadErr.Errors, &FieldError{
+				Field: field.Name,
+				Err:   err,
+			})
+		}
+	}
+
+	if len(loadErr.Errors) > 0 {
+		return loadErr
 	}
 
 	return nil
 }
 
-func parseStruct(st *reflect.Value) []*structField {
+var (
+	snakeCaseAcronym = regexp.MustCompile(`([A-Z]+)([A-Z][a-z])`)
+	snakeCaseWord    = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+)
+
+// toScreamingSnakeCase converts a Go field name such as "DatabaseURL" into the
+// SCREAMING_SNAKE_CASE form used for derived environment variable names, e.g.
+// "DATABASE_URL".
+func toScreamingSnakeCase(name string) string {
+	s := snakeCaseAcronym.ReplaceAllString(name, "${1}_${2}")
+	s = snakeCaseWord.ReplaceAllString(s, "${1}_${2}")
+	return strings.ToUpper(s)
+}
+
+// parseStruct walks t and collects its leaf fields, without touching any actual
+// value: getValue resolves the live struct described by t on demand, and is only
+// invoked once a field's resolver (structField.Value) is called, which in turn
+// only happens once applyFields has confirmed there's a value to write. This
+// keeps pointer fields - the struct's own, or a nested struct's - untouched
+// until then, so a field envcfg has nothing to set stays exactly as the caller
+// left it. prefix is prepended to names derived from field names (it has no
+// effect on an explicit env tag); autoDerive enables deriving names for fields
+// that have no env tag at all, which plain Load intentionally does not do so
+// that untagged fields keep being skipped.
+func parseStruct(t reflect.Type, getValue func() reflect.Value, prefix string, autoDerive bool) []*structField {
 	var fields []*structField
-	t := st.Type()
 
-	for i := 0; i < st.NumField(); i++ {
+	for i := 0; i < t.NumField(); i++ {
 		refField := t.Field(i)
-		refValue := st.Field(i)
 
 		// Skip unexported field
 		if refField.PkgPath != "" {
 			continue
 		}
 
-		if refValue.Kind() == reflect.Struct {
-			// Append fields from the nested struct
-			fields = append(fields, parseStruct(&refValue)...)
-		} else {
-			// Skip field without tag
-			envVarName := refField.Tag.Get("env")
-			if envVarName == "" {
-				continue
+		fieldIndex := i
+		getField := func() reflect.Value {
+			return getValue().Field(fieldIndex)
+		}
+
+		isPtr := refField.Type.Kind() == reflect.Ptr
+		targetType := refField.Type
+		if isPtr {
+			targetType = targetType.Elem()
+		}
+
+		if targetType.Kind() == reflect.Struct && targetType != timeType && !hasCustomDecoder(targetType) {
+			getNested := func() reflect.Value {
+				fv := getField()
+				if isPtr {
+					if fv.IsNil() {
+						fv.Set(reflect.New(targetType))
+					}
+					fv = fv.Elem()
+				}
+				return fv
 			}
 
-			fields = append(fields, &structField{
-				Name:  envVarName,
-				Value: &refValue,
-			})
+			// Append fields from the nested struct. An envPrefix tag stacks onto the
+			// parent prefix; without one, autoDerive falls back to the field name.
+			nestedPrefix := refField.Tag.Get("envPrefix")
+			if nestedPrefix == "" && autoDerive {
+				nestedPrefix = toScreamingSnakeCase(refField.Name) + "_"
+			}
+
+			fields = append(fields, parseStruct(targetType, getNested, prefix+nestedPrefix, autoDerive)...)
+			continue
 		}
+
+		envVarName := refField.Tag.Get("env")
+		if envVarName == "" {
+			// Skip field without tag, unless names are being auto-derived
+			if !autoDerive {
+				continue
+			}
+			envVarName = prefix + toScreamingSnakeCase(refField.Name)
+		}
+
+		fields = append(fields, &structField{
+			Name: envVarName,
+			Value: func() *reflect.Value {
+				fv := getField()
+				if isPtr {
+					if fv.IsNil() {
+						fv.Set(reflect.New(targetType))
+					}
+					fv = fv.Elem()
+				}
+				return &fv
+			},
+			Tag: refField.Tag,
+		})
 	}
 
 	return fields
 }
 
-// Loads values from the specified file and OS environment variables to a structure
-// passed by the reference to the function.
-func LoadFile(filename string, to interface{}) error {
+// options configures a single loading call.
+type options struct {
+	// Prefix is prepended to variable names derived from field names.
+	Prefix string
+	// AutoDerive enables deriving a variable name from the field name when no env
+	// tag is present, instead of skipping the field.
+	AutoDerive bool
+}
+
+func loadFiles(to interface{}, opts *options, paths ...string) error {
 	value := reflect.ValueOf(to)
 
 	// Loading target must be a pointer to structure
@@ -232,24 +816,46 @@ func LoadFile(filename string, to interface{}) error {
 	}
 
 	st := value.Elem()
-	fields := parseStruct(&st)
+	fields := parseStruct(st.Type(), func() reflect.Value { return st }, opts.Prefix, opts.AutoDerive)
 
-	// Load environment variables from .env file
-	err := loadFromFile(filename, fields)
-	if err != nil {
-		// Skip if file doesn't exist
-		if _, statErr := os.Stat(filename); !os.IsNotExist(statErr) {
-			return err
+	vars := make(map[string]string)
+
+	for _, filename := range paths {
+		fileVars, err := decodeFile(filename)
+		if err != nil {
+			// Skip if file doesn't exist
+			if _, statErr := os.Stat(filename); !os.IsNotExist(statErr) {
+				return err
+			}
+			continue
+		}
+
+		for k, v := range fileVars {
+			vars[k] = v
 		}
 	}
 
-	// Override values by loading OS environment variables
-	err = loadFromEnv(fields)
-	if err != nil {
-		return err
+	// Override values with OS environment variables
+	for k, v := range loadFromEnv() {
+		vars[k] = v
 	}
 
-	return nil
+	return applyFields(fields, vars)
+}
+
+// Loads values from the given files, in order, and OS environment variables to a
+// structure passed by the reference to the function. Later files and the OS
+// environment override values loaded from earlier files. The file format is
+// selected by extension; see RegisterFileDecoder for the supported formats and
+// how to add your own.
+func LoadFiles(to interface{}, paths ...string) error {
+	return loadFiles(to, &options{}, paths...)
+}
+
+// Loads values from the specified file and OS environment variables to a structure
+// passed by the reference to the function.
+func LoadFile(filename string, to interface{}) error {
+	return loadFiles(to, &options{}, filename)
 }
 
 // Loads values from .env file located in the current working directory and
@@ -261,5 +867,22 @@ func Load(to interface{}) error {
 	}
 
 	filename := path.Join(dir, ".env")
-	return LoadFile(filename, to)
+	return loadFiles(to, &options{}, filename)
+}
+
+// LoadWithPrefix loads values the same way Load does, but fields without an env tag
+// are no longer skipped: their variable name is derived from the Go field name
+// (converted to SCREAMING_SNAKE_CASE) and prepended with prefix. A nested struct can
+// stack an additional envPrefix tag onto that prefix, or, if it has none, contributes
+// its own derived name, so a Database.Host field under prefix "APP_" resolves to
+// "APP_DATABASE_HOST" by default, or to "APP_DB_HOST" with `envPrefix:"DB_"` on the
+// Database field.
+func LoadWithPrefix(prefix string, to interface{}) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	filename := path.Join(dir, ".env")
+	return loadFiles(to, &options{Prefix: prefix, AutoDerive: true}, filename)
 }
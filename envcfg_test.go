@@ -3,9 +3,11 @@ package envcfg
 import (
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
+	"net"
 	"os"
 	"reflect"
 	"testing"
+	"time"
 )
 
 type Cfg struct {
@@ -27,7 +29,7 @@ func setupEnv() {
 }
 
 func setupEnvFile() *os.File {
-	tmpfile, _ := ioutil.TempFile(tempDir, ".env")
+	tmpfile, _ := ioutil.TempFile(tempDir, "*.env")
 
 	tmpfile.WriteString(`
 BOOL_FIELD=true
@@ -142,6 +144,484 @@ func TestOverrideAll(t *testing.T) {
 	assert.Equal(t, expected, cfg)
 }
 
+func TestSliceMapDurationAndTime(t *testing.T) {
+	type Cfg struct {
+		Tags    []string          `env:"TAGS"`
+		Ports   [3]int            `env:"PORTS"`
+		Labels  map[string]string `env:"LABELS"`
+		Origins []string          `env:"ORIGINS" envSeparator:";"`
+		Timeout time.Duration     `env:"TIMEOUT"`
+		At      time.Time         `env:"AT" envLayout:"2006-01-02"`
+	}
+
+	os.Setenv("TAGS", "a,b,c")
+	os.Setenv("PORTS", "80,443,8080")
+	os.Setenv("LABELS", "env:prod,team:core")
+	os.Setenv("ORIGINS", "http://a.com;http://b.com")
+	os.Setenv("TIMEOUT", "5s")
+	os.Setenv("AT", "2020-01-02")
+	defer os.Clearenv()
+
+	cfg := Cfg{}
+	err := Load(&cfg)
+
+	expected := Cfg{
+		Tags:    []string{"a", "b", "c"},
+		Ports:   [3]int{80, 443, 8080},
+		Labels:  map[string]string{"env": "prod", "team": "core"},
+		Origins: []string{"http://a.com", "http://b.com"},
+		Timeout: 5 * time.Second,
+		At:      time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, cfg)
+}
+
+func TestSliceEmptyValueYieldsEmptySlice(t *testing.T) {
+	type Cfg struct {
+		Tags []string `env:"TAGS"`
+	}
+
+	os.Setenv("TAGS", "")
+	defer os.Clearenv()
+
+	cfg := Cfg{}
+	err := Load(&cfg)
+
+	expected := Cfg{
+		Tags: []string{},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, cfg)
+}
+
+func TestRequiredMissing(t *testing.T) {
+	type Cfg struct {
+		Field string `env:"MISSING_FIELD" envRequired:"true"`
+	}
+
+	cfg := Cfg{}
+	err := Load(&cfg)
+
+	assert.Error(t, err)
+	loadErr, ok := err.(*LoadError)
+	assert.True(t, ok)
+	assert.Len(t, loadErr.Errors, 1)
+	assert.Equal(t, "MISSING_FIELD", loadErr.Errors[0].Field)
+}
+
+func TestDefaultValue(t *testing.T) {
+	type Cfg struct {
+		Field int `env:"MISSING_FIELD" envDefault:"42"`
+	}
+
+	cfg := Cfg{}
+	err := Load(&cfg)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Cfg{Field: 42}, cfg)
+}
+
+func TestLoadErrorCollectsAllFailures(t *testing.T) {
+	type Cfg struct {
+		Required string `env:"REQ_FIELD" envRequired:"true"`
+		Bad      int    `env:"BAD_INT_FIELD"`
+	}
+
+	os.Setenv("BAD_INT_FIELD", "not-a-number")
+	defer os.Clearenv()
+
+	cfg := Cfg{}
+	err := Load(&cfg)
+
+	loadErr, ok := err.(*LoadError)
+	assert.True(t, ok)
+	assert.Len(t, loadErr.Errors, 2)
+}
+
+type ipSetter struct {
+	IP net.IP
+}
+
+func (s *ipSetter) UnmarshalEnv(value string) error {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return &net.ParseError{Type: "IP address", Text: value}
+	}
+	s.IP = ip
+	return nil
+}
+
+func TestSetterInterface(t *testing.T) {
+	type Cfg struct {
+		Host ipSetter `env:"HOST_IP"`
+	}
+
+	os.Setenv("HOST_IP", "127.0.0.1")
+	defer os.Clearenv()
+
+	cfg := Cfg{}
+	err := Load(&cfg)
+
+	assert.NoError(t, err)
+	assert.Equal(t, net.ParseIP("127.0.0.1"), cfg.Host.IP)
+}
+
+func TestRegisterParser(t *testing.T) {
+	RegisterParser(reflect.TypeOf(net.IP{}), func(value string) (interface{}, error) {
+		return net.ParseIP(value), nil
+	})
+
+	type Cfg struct {
+		IP net.IP `env:"CLIENT_IP"`
+	}
+
+	os.Setenv("CLIENT_IP", "10.0.0.1")
+	defer os.Clearenv()
+
+	cfg := Cfg{}
+	err := Load(&cfg)
+
+	assert.NoError(t, err)
+	assert.Equal(t, net.ParseIP("10.0.0.1"), cfg.IP)
+}
+
+func TestPointerField(t *testing.T) {
+	type Cfg struct {
+		Optional *int `env:"OPTIONAL_FIELD"`
+		Untagged *int
+	}
+
+	os.Setenv("OPTIONAL_FIELD", "9")
+	defer os.Clearenv()
+
+	cfg := Cfg{}
+	err := Load(&cfg)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, cfg.Optional)
+	assert.Equal(t, 9, *cfg.Optional)
+	assert.Nil(t, cfg.Untagged)
+}
+
+func TestPointerFieldLeftNilWhenUnset(t *testing.T) {
+	type Database struct {
+		Host string `env:"DB_HOST"`
+	}
+
+	type Cfg struct {
+		Optional *int `env:"MISSING_OPTIONAL_FIELD"`
+		DB       *Database
+	}
+
+	cfg := Cfg{}
+	err := Load(&cfg)
+
+	assert.NoError(t, err)
+	assert.Nil(t, cfg.Optional)
+	assert.Nil(t, cfg.DB)
+}
+
+func setupJSONFile(t *testing.T) string {
+	tmpfile, err := ioutil.TempFile(tempDir, "*.json")
+	assert.NoError(t, err)
+	_, err = tmpfile.WriteString(`{"BOOL_FIELD": true, "INT_FIELD": 100000000, "STRING_FIELD": "from-json", "TAGS": ["a", "b"]}`)
+	assert.NoError(t, err)
+	tmpfile.Close()
+	return tmpfile.Name()
+}
+
+func setupYAMLFile(t *testing.T) string {
+	tmpfile, err := ioutil.TempFile(tempDir, "*.yaml")
+	assert.NoError(t, err)
+	_, err = tmpfile.WriteString("BOOL_FIELD: true\n" +
+		"INT_FIELD: 100000000\n" +
+		"STRING_FIELD: from-yaml\n" +
+		"TAGS:\n  - a\n  - b\n")
+	assert.NoError(t, err)
+	tmpfile.Close()
+	return tmpfile.Name()
+}
+
+func setupTOMLFile(t *testing.T) string {
+	tmpfile, err := ioutil.TempFile(tempDir, "*.toml")
+	assert.NoError(t, err)
+	_, err = tmpfile.WriteString("BOOL_FIELD = true\n" +
+		"INT_FIELD = 100000000\n" +
+		"STRING_FIELD = \"from-toml\"\n" +
+		"TAGS = [\"a\", \"b\"]\n")
+	assert.NoError(t, err)
+	tmpfile.Close()
+	return tmpfile.Name()
+}
+
+func TestLoadJSONFile(t *testing.T) {
+	type Cfg struct {
+		BoolField   bool     `env:"BOOL_FIELD"`
+		IntField    int      `env:"INT_FIELD"`
+		StringField string   `env:"STRING_FIELD"`
+		Tags        []string `env:"TAGS"`
+	}
+
+	filename := setupJSONFile(t)
+	defer os.Remove(filename)
+
+	cfg := Cfg{}
+	err := LoadFile(filename, &cfg)
+
+	expected := Cfg{
+		BoolField:   true,
+		IntField:    100000000,
+		StringField: "from-json",
+		Tags:        []string{"a", "b"},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, cfg)
+}
+
+func TestLoadYAMLFile(t *testing.T) {
+	type Cfg struct {
+		BoolField   bool     `env:"BOOL_FIELD"`
+		IntField    int      `env:"INT_FIELD"`
+		StringField string   `env:"STRING_FIELD"`
+		Tags        []string `env:"TAGS"`
+	}
+
+	filename := setupYAMLFile(t)
+	defer os.Remove(filename)
+
+	cfg := Cfg{}
+	err := LoadFile(filename, &cfg)
+
+	expected := Cfg{
+		BoolField:   true,
+		IntField:    100000000,
+		StringField: "from-yaml",
+		Tags:        []string{"a", "b"},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, cfg)
+}
+
+func TestLoadTOMLFile(t *testing.T) {
+	type Cfg struct {
+		BoolField   bool     `env:"BOOL_FIELD"`
+		IntField    int      `env:"INT_FIELD"`
+		StringField string   `env:"STRING_FIELD"`
+		Tags        []string `env:"TAGS"`
+	}
+
+	filename := setupTOMLFile(t)
+	defer os.Remove(filename)
+
+	cfg := Cfg{}
+	err := LoadFile(filename, &cfg)
+
+	expected := Cfg{
+		BoolField:   true,
+		IntField:    100000000,
+		StringField: "from-toml",
+		Tags:        []string{"a", "b"},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, cfg)
+}
+
+func TestLoadJSONFileFlattensNestedObjects(t *testing.T) {
+	type Database struct {
+		Host string `env:"DB.HOST"`
+		Port int    `env:"DB.PORT"`
+	}
+
+	type Cfg struct {
+		StringField string `env:"STRING_FIELD"`
+		DB          Database
+	}
+
+	tmpfile, err := ioutil.TempFile(tempDir, "*.json")
+	assert.NoError(t, err)
+	_, err = tmpfile.WriteString(`{"STRING_FIELD": "top", "DB": {"HOST": "localhost", "PORT": 5432}}`)
+	assert.NoError(t, err)
+	tmpfile.Close()
+	defer os.Remove(tmpfile.Name())
+
+	cfg := Cfg{}
+	err = LoadFile(tmpfile.Name(), &cfg)
+
+	expected := Cfg{
+		StringField: "top",
+		DB: Database{
+			Host: "localhost",
+			Port: 5432,
+		},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, cfg)
+}
+
+func TestLoadFilesMergesInOrder(t *testing.T) {
+	base, err := ioutil.TempFile(tempDir, "*.env")
+	assert.NoError(t, err)
+	base.WriteString("BOOL_FIELD=false\nINT_FIELD=1")
+	base.Close()
+	defer os.Remove(base.Name())
+
+	override, err := ioutil.TempFile(tempDir, "*.env")
+	assert.NoError(t, err)
+	override.WriteString("BOOL_FIELD=true")
+	override.Close()
+	defer os.Remove(override.Name())
+
+	cfg := Cfg{}
+	err = LoadFiles(&cfg, base.Name(), override.Name())
+
+	expected := Cfg{
+		BoolField: true,
+		IntField:  1,
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, cfg)
+}
+
+func TestEnvFileQuotingEscapesAndExpansion(t *testing.T) {
+	tmpfile, err := ioutil.TempFile(tempDir, "*.env")
+	assert.NoError(t, err)
+	tmpfile.WriteString("export STRING_FIELD=\"line one\\nline two # not a comment\"\n" +
+		"BASE=hello\n" +
+		"EXPANDED=${BASE} world\n" +
+		"FALLBACK=${MISSING_VAR:-fallback}\n")
+	tmpfile.Close()
+	defer os.Remove(tmpfile.Name())
+
+	type Cfg struct {
+		StringField string `env:"STRING_FIELD"`
+		Expanded    string `env:"EXPANDED"`
+		Fallback    string `env:"FALLBACK"`
+	}
+
+	cfg := Cfg{}
+	err = LoadFile(tmpfile.Name(), &cfg)
+
+	expected := Cfg{
+		StringField: "line one\nline two # not a comment",
+		Expanded:    "hello world",
+		Fallback:    "fallback",
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, cfg)
+}
+
+func TestEnvFileUnresolvedVarRefLeftLiteral(t *testing.T) {
+	tmpfile, err := ioutil.TempFile(tempDir, "*.env")
+	assert.NoError(t, err)
+	tmpfile.WriteString("HASH=$2b$10$abcdefghijklmnopqrstuv\n" +
+		"QUOTED_HASH=\"$2b$10$abcdefghijklmnopqrstuv\"\n")
+	tmpfile.Close()
+	defer os.Remove(tmpfile.Name())
+
+	type Cfg struct {
+		Hash       string `env:"HASH"`
+		QuotedHash string `env:"QUOTED_HASH"`
+	}
+
+	cfg := Cfg{}
+	err = LoadFile(tmpfile.Name(), &cfg)
+
+	expected := Cfg{
+		Hash:       "$2b$10$abcdefghijklmnopqrstuv",
+		QuotedHash: "$2b$10$abcdefghijklmnopqrstuv",
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, cfg)
+}
+
+func TestEnvFileSingleQuotedAndMultilineValue(t *testing.T) {
+	tmpfile, err := ioutil.TempFile(tempDir, "*.env")
+	assert.NoError(t, err)
+	tmpfile.WriteString("SINGLE_QUOTED='no $expansion here'\n" +
+		"MULTILINE=\"line one\nline two\"\n")
+	tmpfile.Close()
+	defer os.Remove(tmpfile.Name())
+
+	type Cfg struct {
+		SingleQuoted string `env:"SINGLE_QUOTED"`
+		Multiline    string `env:"MULTILINE"`
+	}
+
+	cfg := Cfg{}
+	err = LoadFile(tmpfile.Name(), &cfg)
+
+	expected := Cfg{
+		SingleQuoted: "no $expansion here",
+		Multiline:    "line one\nline two",
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, cfg)
+}
+
+func TestEnvFileMultilineValueWithoutTrailingNewline(t *testing.T) {
+	tmpfile, err := ioutil.TempFile(tempDir, "*.env")
+	assert.NoError(t, err)
+	tmpfile.WriteString("MULTILINE=\"line one\nline two\"")
+	tmpfile.Close()
+	defer os.Remove(tmpfile.Name())
+
+	type Cfg struct {
+		Multiline string `env:"MULTILINE"`
+	}
+
+	cfg := Cfg{}
+	err = LoadFile(tmpfile.Name(), &cfg)
+
+	expected := Cfg{
+		Multiline: "line one\nline two",
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, cfg)
+}
+
+func TestLoadWithPrefixAutoDerivesNames(t *testing.T) {
+	type Database struct {
+		Host string
+		Port int
+	}
+
+	type Cfg struct {
+		Debug    bool
+		Database Database `envPrefix:"DB_"`
+	}
+
+	os.Setenv("APP_DEBUG", "true")
+	os.Setenv("APP_DB_HOST", "localhost")
+	os.Setenv("APP_DB_PORT", "5432")
+	defer os.Clearenv()
+
+	cfg := Cfg{}
+	err := LoadWithPrefix("APP_", &cfg)
+
+	expected := Cfg{
+		Debug: true,
+		Database: Database{
+			Host: "localhost",
+			Port: 5432,
+		},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, cfg)
+}
+
 func TestNestedStruct(t *testing.T) {
 	type Nested struct {
 		Field int `env:"INT_FIELD"`